@@ -29,6 +29,22 @@ type AddFunc func(BrowseEntry)
 // RmvFunc is called when a service instance disappared.
 type RmvFunc func(BrowseEntry)
 
+// QueryStrategy controls how lookupType behaves on the wire.
+type QueryStrategy int
+
+const (
+	// QueryActive sends a single PTR query and relies on the normal
+	// cache/TTL bookkeeping for updates.
+	QueryActive QueryStrategy = iota
+	// QueryContinuous re-sends the PTR query using Continuous Multicast
+	// DNS Querying (RFC 6762 §5.2) backoff.
+	QueryContinuous
+	// QueryPassive never sends a query. It only joins the multicast
+	// group and populates entries from unsolicited announcements and
+	// other nodes' queries/responses.
+	QueryPassive
+)
+
 // LookupType browses for service instances.
 func LookupType(ctx context.Context, service string, add AddFunc, rmv RmvFunc) (err error) {
 	conn, err := newMDNSConn()
@@ -37,18 +53,25 @@ func LookupType(ctx context.Context, service string, add AddFunc, rmv RmvFunc) (
 	}
 	defer conn.close()
 
-	return lookupType(ctx, service, conn, add, rmv, false)
+	return lookupType(ctx, service, conn, add, rmv, QueryActive)
 }
 
 // LookupTypeAtInterface browses for service instances at specific network interfaces.
 func LookupTypeAtInterfaces(ctx context.Context, service string, add AddFunc, rmv RmvFunc, ifaces ...string) (err error) {
+	return LookupTypeAtInterfacesWithStrategy(ctx, service, add, rmv, QueryActive, ifaces...)
+}
+
+// LookupTypeAtInterfacesWithStrategy is like LookupTypeAtInterfaces, but lets
+// the caller pick the QueryStrategy, e.g. QueryPassive to only observe the
+// network without ever sending a PTR question.
+func LookupTypeAtInterfacesWithStrategy(ctx context.Context, service string, add AddFunc, rmv RmvFunc, strategy QueryStrategy, ifaces ...string) (err error) {
 	conn, err := newMDNSConn(ifaces...)
 	if err != nil {
 		return err
 	}
 	defer conn.close()
 
-	return lookupType(ctx, service, conn, add, rmv, false, ifaces...)
+	return lookupType(ctx, service, conn, add, rmv, strategy, ifaces...)
 }
 
 // LookupTypeContinuously brwoses for service instances using Continuous Multicast DNS Querying
@@ -59,7 +82,23 @@ func LookupTypeContinuously(ctx context.Context, service string, add AddFunc, rm
 	}
 	defer conn.close()
 
-	return lookupType(ctx, service, conn, add, rmv, true)
+	return lookupType(ctx, service, conn, add, rmv, QueryContinuous)
+}
+
+// LookupTypePassive browses for service instances purely by observing mDNS
+// traffic: it joins the multicast group and populates entries from
+// unsolicited announcements and other nodes' queries/responses, but never
+// sends a PTR question itself. This is useful on constrained or
+// battery-powered devices, and in environments where generating traffic
+// to discover services is undesirable.
+func LookupTypePassive(ctx context.Context, service string, add AddFunc, rmv RmvFunc) (err error) {
+	conn, err := newMDNSConn()
+	if err != nil {
+		return err
+	}
+	defer conn.close()
+
+	return lookupType(ctx, service, conn, add, rmv, QueryPassive)
 }
 
 // ServiceInstanceName returns the service instance name
@@ -75,7 +114,7 @@ func (e BrowseEntry) ServiceInstanceName() string {
 	return fmt.Sprintf("%s.%s.%s.", e.Name, e.Type, e.Domain)
 }
 
-func lookupType(ctx context.Context, service string, conn MDNSConn, add AddFunc, rmv RmvFunc, continuous bool, ifaces ...string) (err error) {
+func lookupType(ctx context.Context, service string, conn MDNSConn, add AddFunc, rmv RmvFunc, strategy QueryStrategy, ifaces ...string) (err error) {
 	var cache = NewCache()
 
 	m := new(dns.Msg)
@@ -93,50 +132,49 @@ func lookupType(ctx context.Context, service string, conn MDNSConn, add AddFunc,
 	ch := conn.Read(readCtx)
 
 	qs := make(chan *Query)
-	go func() {
-		query := func() {
-			for _, iface := range MulticastInterfaces(ifaces...) {
-				iface := iface
-				q := &Query{msg: m.Copy(), iface: iface}
-				qs <- q
+	trigger := make(chan struct{}, 1)
+	if strategy != QueryPassive {
+		go runQueryStrategy(ctx, m, strategy, ifaces, qs, trigger)
+	}
+
+	var watch <-chan InterfaceChange
+	if strategy == QueryContinuous {
+		watch = WatchInterfaces(ctx, 5*time.Second)
+	}
+
+	observedAdd := func(e BrowseEntry) {
+		observer().EntryAdded(service)
+		add(e)
+	}
+	observedRmv := func(e BrowseEntry) {
+		observer().EntryRemoved(service)
+		rmv(e)
+	}
+
+	seenInstances := map[string]bool{}
+	es := []*BrowseEntry{}
+	for {
+		select {
+		case change, ok := <-watch:
+			if !ok {
+				watch = nil
+				continue
 			}
-		}
 
-		// Add random delay（between 20ms and 120ms）for first query
-		time.Sleep(time.Duration(rand.Intn(100)+20) * time.Millisecond)
-
-		if continuous {
-			counter := 0
-			interval := time.Duration(0)
-			for {
-				query()
-
-				if interval < time.Hour {
-					// Exponential backoff: increase the interval
-					interval = time.Duration(1<<counter) * time.Second
-					if interval >= time.Hour || interval < 0 {
-						// If the interval exceeds 60 minutes or is negative (overflow),
-						// Cap the interval to 60 minutes
-						interval = time.Hour
-					}
+			purgeInterfacesFromCache(cache, change.Down)
+			es = removeEntriesForInterfaces(es, change.Down, observedRmv)
+
+			if len(change.Up) > 0 {
+				if err := conn.JoinInterfaces(change.Up...); err != nil {
+					log.Debug.Println("JoinInterfaces:", err)
 				}
 
 				select {
-				case <-time.After(interval):
-					counter += 1
-
-				case <-ctx.Done():
-					return
+				case trigger <- struct{}{}:
+				default:
 				}
 			}
-		} else {
-			query()
-		}
-	}()
 
-	es := []*BrowseEntry{}
-	for {
-		select {
 		case q := <-qs:
 			log.Debug.Printf("Send browsing query at %s\n%s\n", q.IfaceName(), q.msg)
 			// Known-Answer Supression
@@ -151,13 +189,31 @@ func lookupType(ctx context.Context, service string, conn MDNSConn, add AddFunc,
 				}
 			}
 			q.msg.Answer = answer
+			observer().QuerySent(service, q.IfaceName())
+			observer().KnownAnswersSuppressed(service, q.IfaceName(), len(answer))
 			if err := conn.SendQuery(q); err != nil {
 				log.Debug.Println("SendQuery:", err)
 			}
 
 		case req := <-ch:
 			log.Debug.Printf("Receive message at %s\n%s\n", req.IfaceName(), req.msg)
+			observer().MessageReceived(req.IfaceName())
+
+			// RFC 6762 §10.1: a PTR/SRV record with TTL 0 is a "goodbye"
+			// packet. Remove the affected entries right away instead of
+			// waiting for the cache to expire them.
+			es = removeGoneEntries(es, goodbyeInstanceNames(req.msg), observedRmv)
+
 			cache.UpdateFrom(req)
+			observer().CacheUpdated(service)
+			for _, name := range messageInstanceNames(req.msg) {
+				if seenInstances[name] {
+					observer().CacheHit(service)
+				} else {
+					observer().CacheMiss(service)
+					seenInstances[name] = true
+				}
+			}
 			for _, srv := range cache.Services() {
 				if srv.ServiceName() != service {
 					continue
@@ -183,7 +239,7 @@ func lookupType(ctx context.Context, service string, conn MDNSConn, add AddFunc,
 							Text:      srv.Text,
 						}
 						es = append(es, &e)
-						add(e)
+						observedAdd(e)
 					}
 				}
 			}
@@ -202,12 +258,68 @@ func lookupType(ctx context.Context, service string, conn MDNSConn, add AddFunc,
 					tmp = append(tmp, e)
 				} else {
 					// TODO
-					rmv(*e)
+					observedRmv(*e)
 				}
 			}
 			es = tmp
 		case <-ctx.Done():
+			observer().LookupFailed(service, ctx.Err())
 			return ctx.Err()
 		}
 	}
 }
+
+// runQueryStrategy drives the PTR query according to strategy, sending
+// one *Query per matching interface on qs every time it fires. For
+// QueryContinuous, a signal on trigger (e.g. an interface coming up)
+// re-issues the query immediately and resets the backoff. It returns once
+// ctx is done. Callers must not invoke it for QueryPassive, which sends
+// no queries at all.
+func runQueryStrategy(ctx context.Context, m *dns.Msg, strategy QueryStrategy, ifaces []string, qs chan<- *Query, trigger <-chan struct{}) {
+	query := func() {
+		for _, iface := range MulticastInterfaces(ifaces...) {
+			iface := iface
+			q := &Query{msg: m.Copy(), iface: iface}
+			qs <- q
+		}
+	}
+
+	// Add random delay（between 20ms and 120ms）for first query
+	time.Sleep(time.Duration(rand.Intn(100)+20) * time.Millisecond)
+
+	if strategy != QueryContinuous {
+		query()
+		return
+	}
+
+	counter := 0
+	interval := time.Duration(0)
+	for {
+		query()
+
+		if interval < time.Hour {
+			// Exponential backoff: increase the interval
+			interval = time.Duration(1<<counter) * time.Second
+			if interval >= time.Hour || interval < 0 {
+				// If the interval exceeds 60 minutes or is negative (overflow),
+				// Cap the interval to 60 minutes
+				interval = time.Hour
+			}
+		}
+
+		select {
+		case <-time.After(interval):
+			counter += 1
+
+		case <-trigger:
+			// A watched interface came up (lookupType has already joined
+			// it): query again right away, which re-reads
+			// MulticastInterfaces, and restart backoff.
+			counter = 0
+			interval = 0
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package dnssd
+
+import (
+	"github.com/miekg/dns"
+)
+
+// isGoodbye reports whether rr is an RFC 6762 §10.1 "goodbye" record,
+// i.e. a PTR or SRV record announced with TTL 0 to signal that a service
+// instance is going away right now, instead of waiting for its TTL to
+// lapse.
+func isGoodbye(rr dns.RR) bool {
+	if rr.Header().Ttl != 0 {
+		return false
+	}
+
+	switch rr.(type) {
+	case *dns.PTR, *dns.SRV:
+		return true
+	default:
+		return false
+	}
+}
+
+// goodbyeInstanceNames returns the service instance names (owner names,
+// fully qualified) that msg announces as gone via a goodbye record.
+func goodbyeInstanceNames(msg *dns.Msg) []string {
+	var names []string
+	for _, rr := range msg.Answer {
+		if !isGoodbye(rr) {
+			continue
+		}
+
+		switch v := rr.(type) {
+		case *dns.PTR:
+			names = append(names, v.Ptr)
+		case *dns.SRV:
+			names = append(names, v.Hdr.Name)
+		}
+	}
+	return names
+}
+
+// removeGoneEntries removes from es every entry whose instance name is in
+// names, invoking rmv for each one immediately instead of waiting for the
+// entry to age out of the cache, and returns the entries that remain.
+//
+// names holds wire-form owner names, which miekg/dns escapes per RFC 1035
+// presentation rules (spaces, dots within a label, etc.), so entries are
+// matched against EscapedServiceInstanceName rather than the unescaped
+// ServiceInstanceName.
+func removeGoneEntries(es []*BrowseEntry, names []string, rmv RmvFunc) []*BrowseEntry {
+	if len(names) == 0 {
+		return es
+	}
+
+	remaining := []*BrowseEntry{}
+	for _, e := range es {
+		gone := false
+		for _, name := range names {
+			if e.EscapedServiceInstanceName() == name {
+				gone = true
+				break
+			}
+		}
+
+		if gone {
+			rmv(*e)
+		} else {
+			remaining = append(remaining, e)
+		}
+	}
+	return remaining
+}
+
+// messageInstanceNames returns the distinct service instance names
+// carried by the PTR (target), SRV and TXT (owner name) records of msg's
+// answer section; other record types are skipped. It is used to tell cache hits (an already-known instance being
+// refreshed) from cache misses (a newly-seen instance).
+func messageInstanceNames(msg *dns.Msg) []string {
+	var names []string
+	seen := map[string]bool{}
+
+	for _, rr := range msg.Answer {
+		var name string
+		switch v := rr.(type) {
+		case *dns.PTR:
+			name = v.Ptr
+		case *dns.SRV, *dns.TXT:
+			name = rr.Header().Name
+		default:
+			continue
+		}
+
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// GoodbyeRecords returns copies of ptr, srv and txt with their TTL set to
+// zero, as RFC 6762 §10.1 requires for announcing that a service instance
+// is being withdrawn. The shared host A/AAAA records are intentionally
+// left out: callers publish those separately and other services on the
+// same host may still depend on them.
+func GoodbyeRecords(ptr, srv, txt dns.RR) []dns.RR {
+	rrs := make([]dns.RR, 0, 3)
+	for _, rr := range []dns.RR{ptr, srv, txt} {
+		if rr == nil {
+			continue
+		}
+		rr = dns.Copy(rr)
+		rr.Header().Ttl = 0
+		rrs = append(rrs, rr)
+	}
+	return rrs
+}
@@ -0,0 +1,132 @@
+package dnssd
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func ptrRR(owner, target string, ttl uint32) *dns.PTR {
+	return &dns.PTR{
+		Hdr: dns.RR_Header{Name: owner, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: ttl},
+		Ptr: target,
+	}
+}
+
+func srvRR(owner string, ttl uint32) *dns.SRV {
+	return &dns.SRV{
+		Hdr: dns.RR_Header{Name: owner, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: ttl},
+	}
+}
+
+func txtRR(owner string, ttl uint32) *dns.TXT {
+	return &dns.TXT{
+		Hdr: dns.RR_Header{Name: owner, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: ttl},
+	}
+}
+
+func TestIsGoodbye(t *testing.T) {
+	tests := []struct {
+		name string
+		rr   dns.RR
+		want bool
+	}{
+		{"ptr with ttl 0 is goodbye", ptrRR("_http._tcp.local.", "My Printer._http._tcp.local.", 0), true},
+		{"srv with ttl 0 is goodbye", srvRR("My Printer._http._tcp.local.", 0), true},
+		{"ptr with positive ttl is not goodbye", ptrRR("_http._tcp.local.", "My Printer._http._tcp.local.", 120), false},
+		{"txt with ttl 0 is not a goodbye record", txtRR("My Printer._http._tcp.local.", 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGoodbye(tt.rr); got != tt.want {
+				t.Errorf("isGoodbye(%v) = %v, want %v", tt.rr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoodbyeInstanceNames(t *testing.T) {
+	const instance = "My Printer._http._tcp.local."
+
+	m := new(dns.Msg)
+	m.Answer = []dns.RR{
+		ptrRR("_http._tcp.local.", instance, 0),
+		srvRR("Other._http._tcp.local.", 120),
+		txtRR(instance, 0),
+	}
+
+	names := goodbyeInstanceNames(m)
+	if len(names) != 1 || names[0] != instance {
+		t.Fatalf("goodbyeInstanceNames() = %v, want [%s]", names, instance)
+	}
+}
+
+func TestMessageInstanceNames(t *testing.T) {
+	const a = "A._http._tcp.local."
+	const b = "B._http._tcp.local."
+
+	m := new(dns.Msg)
+	m.Answer = []dns.RR{
+		ptrRR("_http._tcp.local.", a, 120),
+		srvRR(a, 120),
+		txtRR(b, 120),
+	}
+
+	names := messageInstanceNames(m)
+	if len(names) != 2 || names[0] != a || names[1] != b {
+		t.Fatalf("messageInstanceNames() = %v, want [%s %s]", names, a, b)
+	}
+}
+
+func TestRemoveGoneEntries(t *testing.T) {
+	gone := &BrowseEntry{Name: "My Printer", Type: "_http._tcp", Domain: "local"}
+	stays := &BrowseEntry{Name: "Other", Type: "_http._tcp", Domain: "local"}
+	es := []*BrowseEntry{gone, stays}
+
+	var removed []BrowseEntry
+	remaining := removeGoneEntries(es, []string{gone.EscapedServiceInstanceName()}, func(e BrowseEntry) {
+		removed = append(removed, e)
+	})
+
+	if len(remaining) != 1 || remaining[0] != stays {
+		t.Fatalf("removeGoneEntries() remaining = %v, want [%v]", remaining, stays)
+	}
+	if len(removed) != 1 || removed[0].Name != gone.Name {
+		t.Fatalf("removeGoneEntries() removed = %v, want [%v]", removed, *gone)
+	}
+}
+
+// TestRemoveGoneEntriesWireEscaping exercises real RFC 1035 presentation
+// escaping instead of a literal test string: an instance name containing a
+// space comes back from Msg.Pack/Unpack with the space backslash-escaped,
+// and removeGoneEntries must match that escaped form against
+// BrowseEntry.EscapedServiceInstanceName, not the unescaped name.
+func TestRemoveGoneEntriesWireEscaping(t *testing.T) {
+	gone := &BrowseEntry{Name: "My Printer", Type: "_http._tcp", Domain: "local"}
+	es := []*BrowseEntry{gone}
+
+	m := new(dns.Msg)
+	m.Answer = []dns.RR{ptrRR("_http._tcp.local.", gone.EscapedServiceInstanceName(), 0)}
+
+	packed, err := m.Pack()
+	if err != nil {
+		t.Fatalf("Pack() failed: %v", err)
+	}
+	var wire dns.Msg
+	if err := wire.Unpack(packed); err != nil {
+		t.Fatalf("Unpack() failed: %v", err)
+	}
+
+	var removed []BrowseEntry
+	remaining := removeGoneEntries(es, goodbyeInstanceNames(&wire), func(e BrowseEntry) {
+		removed = append(removed, e)
+	})
+
+	if len(remaining) != 0 {
+		t.Fatalf("removeGoneEntries() remaining = %v, want none", remaining)
+	}
+	if len(removed) != 1 || removed[0].Name != gone.Name {
+		t.Fatalf("removeGoneEntries() removed = %v, want [%v]", removed, *gone)
+	}
+}
@@ -0,0 +1,133 @@
+package dnssd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/brutella/dnssd/log"
+	"github.com/miekg/dns"
+)
+
+// LookupInstance resolves a single, fully-qualified service instance by
+// sending direct SRV and TXT queries for it, rather than browsing for
+// instances of a service type via PTR like LookupType does. It resolves
+// the instance's A/AAAA records in the same pass. Use it when the
+// instance name is already known, e.g. from a QR code or a config file,
+// to skip the browse round trip.
+func LookupInstance(ctx context.Context, instance, service, domain string, add AddFunc, rmv RmvFunc) (err error) {
+	conn, err := newMDNSConn()
+	if err != nil {
+		return err
+	}
+	defer conn.close()
+
+	name := fmt.Sprintf("%s.%s.%s.", escape.Replace(instance), service, domain)
+
+	m := new(dns.Msg)
+	m.Question = []dns.Question{
+		{Name: name, Qtype: dns.TypeSRV, Qclass: dns.ClassINET},
+		{Name: name, Qtype: dns.TypeTXT, Qclass: dns.ClassINET},
+	}
+
+	return lookupInstance(ctx, name, conn, add, rmv, m)
+}
+
+// lookupInstance reuses lookupType's cache/read plumbing, but seeds the
+// query with SRV/TXT questions for a single instance name instead of a
+// PTR browse, and matches cache updates by instance name instead of
+// aggregating by service name across interfaces.
+func lookupInstance(ctx context.Context, name string, conn MDNSConn, add AddFunc, rmv RmvFunc, m *dns.Msg) (err error) {
+	var cache = NewCache()
+
+	readCtx, readCancel := context.WithCancel(ctx)
+	defer readCancel()
+
+	ch := conn.Read(readCtx)
+
+	qs := make(chan *Query)
+	trigger := make(chan struct{}, 1)
+	go runQueryStrategy(ctx, m, QueryContinuous, nil, qs, trigger)
+
+	var e *BrowseEntry
+	for {
+		select {
+		case q := <-qs:
+			log.Debug.Printf("Send instance query at %s\n%s\n", q.IfaceName(), q.msg)
+			if err := conn.SendQuery(q); err != nil {
+				log.Debug.Println("SendQuery:", err)
+			}
+
+		case req := <-ch:
+			log.Debug.Printf("Receive message at %s\n%s\n", req.IfaceName(), req.msg)
+
+			for _, goodbye := range goodbyeInstanceNames(req.msg) {
+				if goodbye == name && e != nil {
+					rmv(*e)
+					e = nil
+				}
+			}
+
+			cache.UpdateFrom(req)
+
+			found := instanceEntry(cache, name)
+
+			switch {
+			case found == nil && e != nil:
+				rmv(*e)
+				e = nil
+			case found != nil && e == nil:
+				e = found
+				add(*e)
+			case found != nil && e != nil && !entriesEqual(*e, *found):
+				// The instance is still here, but its address or TXT
+				// data changed: tell the caller instead of keeping the
+				// stale entry around until the instance disappears.
+				rmv(*e)
+				e = found
+				add(*e)
+			}
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// instanceEntry returns the BrowseEntry for name from cache. name is the
+// escaped form produced by escape.Replace, so it's matched against
+// EscapedServiceInstanceName rather than the unescaped
+// ServiceInstanceName. An instance can be known on more than one
+// interface; the lexicographically smallest interface name is picked so
+// the result is deterministic across calls, instead of depending on Go's
+// randomized map iteration order.
+func instanceEntry(cache *Cache, name string) *BrowseEntry {
+	for _, srv := range cache.Services() {
+		if srv.EscapedServiceInstanceName() != name {
+			continue
+		}
+
+		if len(srv.ifaceIPs) == 0 {
+			return nil
+		}
+
+		ifaceNames := make([]string, 0, len(srv.ifaceIPs))
+		for ifaceName := range srv.ifaceIPs {
+			ifaceNames = append(ifaceNames, ifaceName)
+		}
+		sort.Strings(ifaceNames)
+		ifaceName := ifaceNames[0]
+
+		return &BrowseEntry{
+			IPs:       srv.ifaceIPs[ifaceName],
+			Host:      srv.Host,
+			Port:      srv.Port,
+			IfaceName: ifaceName,
+			Name:      srv.Name,
+			Type:      srv.Type,
+			Domain:    srv.Domain,
+			Text:      srv.Text,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package dnssd
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// InterfaceChange describes how the set of usable network interfaces
+// changed between two polls of WatchInterfaces.
+type InterfaceChange struct {
+	// Up lists interfaces that appeared or became usable: up, multicast
+	// capable and carrying at least one address.
+	Up []string
+	// Down lists interfaces that disappeared or stopped being usable.
+	Down []string
+}
+
+// WatchInterfaces polls net.Interfaces and reports on the returned channel
+// whenever the set of multicast-capable, up interfaces changes, until ctx
+// is done. LookupTypeContinuously uses it to notice when a host roams
+// between networks (e.g. a laptop switching Wi-Fi networks, or a phone
+// losing Wi-Fi for cellular), which a purely time-based re-query would
+// otherwise miss.
+func WatchInterfaces(ctx context.Context, interval time.Duration) <-chan InterfaceChange {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ch := make(chan InterfaceChange)
+	go func() {
+		defer close(ch)
+
+		prev, _ := usableInterfaceNames()
+		for {
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
+
+			cur, err := usableInterfaceNames()
+			if err != nil {
+				continue
+			}
+
+			if change := diffInterfaceNames(prev, cur); len(change.Up) > 0 || len(change.Down) > 0 {
+				select {
+				case ch <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = cur
+		}
+	}()
+
+	return ch
+}
+
+// usableInterfaceNames returns the set of interfaces that are up,
+// multicast-capable and carry at least one address.
+func usableInterfaceNames() (map[string]bool, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+
+		names[iface.Name] = true
+	}
+	return names, nil
+}
+
+func diffInterfaceNames(prev, cur map[string]bool) InterfaceChange {
+	var change InterfaceChange
+	for name := range cur {
+		if !prev[name] {
+			change.Up = append(change.Up, name)
+		}
+	}
+	for name := range prev {
+		if !cur[name] {
+			change.Down = append(change.Down, name)
+		}
+	}
+	return change
+}
+
+// purgeInterfacesFromCache drops the per-interface records of down from
+// cache. Without this, the next received message would still see
+// srv.ifaceIPs[iface] for a vanished iface and the "add missing entries"
+// loop in lookupType would immediately re-add it from stale data.
+func purgeInterfacesFromCache(cache *Cache, down []string) {
+	if len(down) == 0 {
+		return
+	}
+
+	for _, srv := range cache.Services() {
+		for _, iface := range down {
+			delete(srv.ifaceIPs, iface)
+		}
+	}
+}
+
+// removeEntriesForInterfaces removes from es every entry whose IfaceName
+// is in down, invoking rmv for each, and returns the entries that remain.
+// It is used to flush entries scoped to interfaces that just vanished.
+func removeEntriesForInterfaces(es []*BrowseEntry, down []string, rmv RmvFunc) []*BrowseEntry {
+	if len(down) == 0 {
+		return es
+	}
+
+	remaining := []*BrowseEntry{}
+	for _, e := range es {
+		vanished := false
+		for _, name := range down {
+			if e.IfaceName == name {
+				vanished = true
+				break
+			}
+		}
+
+		if vanished {
+			rmv(*e)
+		} else {
+			remaining = append(remaining, e)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,67 @@
+package dnssd
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func namesOf(names ...string) map[string]bool {
+	m := map[string]bool{}
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+func sorted(ss []string) []string {
+	out := append([]string{}, ss...)
+	sort.Strings(out)
+	return out
+}
+
+func TestDiffInterfaceNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     map[string]bool
+		cur      map[string]bool
+		wantUp   []string
+		wantDown []string
+	}{
+		{"no change", namesOf("en0"), namesOf("en0"), nil, nil},
+		{"interface appeared", namesOf("en0"), namesOf("en0", "en1"), []string{"en1"}, nil},
+		{"interface vanished", namesOf("en0", "en1"), namesOf("en0"), nil, []string{"en1"}},
+		{"interface swapped", namesOf("en0"), namesOf("en1"), []string{"en1"}, []string{"en0"}},
+		{"starting from nothing", nil, namesOf("en0"), []string{"en0"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := diffInterfaceNames(tt.prev, tt.cur)
+			if !reflect.DeepEqual(sorted(change.Up), sorted(tt.wantUp)) {
+				t.Errorf("Up = %v, want %v", change.Up, tt.wantUp)
+			}
+			if !reflect.DeepEqual(sorted(change.Down), sorted(tt.wantDown)) {
+				t.Errorf("Down = %v, want %v", change.Down, tt.wantDown)
+			}
+		})
+	}
+}
+
+func TestRemoveEntriesForInterfaces(t *testing.T) {
+	en0 := &BrowseEntry{Name: "A", IfaceName: "en0"}
+	en1 := &BrowseEntry{Name: "B", IfaceName: "en1"}
+	es := []*BrowseEntry{en0, en1}
+
+	var removed []BrowseEntry
+	remaining := removeEntriesForInterfaces(es, []string{"en0"}, func(e BrowseEntry) {
+		removed = append(removed, e)
+	})
+
+	if len(remaining) != 1 || remaining[0] != en1 {
+		t.Fatalf("remaining = %v, want [%v]", remaining, en1)
+	}
+	if len(removed) != 1 || removed[0].Name != "A" {
+		t.Fatalf("removed = %v, want [A]", removed)
+	}
+}
@@ -0,0 +1,136 @@
+// Package metrics provides dnssd.Observer implementations for
+// instrumenting github.com/brutella/dnssd in production.
+package metrics
+
+import (
+	"github.com/brutella/dnssd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusObserver is a dnssd.Observer that exposes query/response
+// accounting as Prometheus counters, labeled by service type and, where
+// it applies, network interface.
+type PrometheusObserver struct {
+	QueriesSent            *prometheus.CounterVec
+	KnownAnswersSuppressed *prometheus.CounterVec
+	MessagesReceived       *prometheus.CounterVec
+	CacheUpdates           *prometheus.CounterVec
+	CacheHits              *prometheus.CounterVec
+	CacheMisses            *prometheus.CounterVec
+	EntriesAdded           *prometheus.CounterVec
+	EntriesRemoved         *prometheus.CounterVec
+	LookupFailures         *prometheus.CounterVec
+}
+
+// NewPrometheusObserver creates a PrometheusObserver and registers its
+// counters with reg.
+func NewPrometheusObserver(reg prometheus.Registerer) *PrometheusObserver {
+	o := &PrometheusObserver{
+		QueriesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "queries_sent_total",
+			Help:      "Number of PTR queries sent, by service type and interface.",
+		}, []string{"service", "iface"}),
+		KnownAnswersSuppressed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "known_answers_suppressed_total",
+			Help:      "Number of known answers attached to queries for known-answer suppression.",
+		}, []string{"service", "iface"}),
+		MessagesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "messages_received_total",
+			Help:      "Number of mDNS messages received, by interface.",
+		}, []string{"iface"}),
+		CacheUpdates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "cache_updates_total",
+			Help:      "Number of received messages applied to a lookup's cache, by service type.",
+		}, []string{"service"}),
+		CacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "cache_hits_total",
+			Help:      "Number of instance names in received messages that the lookup had already seen, by service type.",
+		}, []string{"service"}),
+		CacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "cache_misses_total",
+			Help:      "Number of instance names in received messages that the lookup saw for the first time, by service type.",
+		}, []string{"service"}),
+		EntriesAdded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "entries_added_total",
+			Help:      "Number of BrowseEntry values handed to an AddFunc, by service type.",
+		}, []string{"service"}),
+		EntriesRemoved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "entries_removed_total",
+			Help:      "Number of BrowseEntry values handed to an RmvFunc, by service type.",
+		}, []string{"service"}),
+		LookupFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dnssd",
+			Name:      "lookup_failures_total",
+			Help:      "Number of lookups that ended in an error, by service type.",
+		}, []string{"service"}),
+	}
+
+	reg.MustRegister(
+		o.QueriesSent,
+		o.KnownAnswersSuppressed,
+		o.MessagesReceived,
+		o.CacheUpdates,
+		o.CacheHits,
+		o.CacheMisses,
+		o.EntriesAdded,
+		o.EntriesRemoved,
+		o.LookupFailures,
+	)
+
+	return o
+}
+
+var _ dnssd.Observer = (*PrometheusObserver)(nil)
+
+// QuerySent implements dnssd.Observer.
+func (o *PrometheusObserver) QuerySent(service, iface string) {
+	o.QueriesSent.WithLabelValues(service, iface).Inc()
+}
+
+// KnownAnswersSuppressed implements dnssd.Observer.
+func (o *PrometheusObserver) KnownAnswersSuppressed(service, iface string, count int) {
+	o.KnownAnswersSuppressed.WithLabelValues(service, iface).Add(float64(count))
+}
+
+// MessageReceived implements dnssd.Observer.
+func (o *PrometheusObserver) MessageReceived(iface string) {
+	o.MessagesReceived.WithLabelValues(iface).Inc()
+}
+
+// CacheUpdated implements dnssd.Observer.
+func (o *PrometheusObserver) CacheUpdated(service string) {
+	o.CacheUpdates.WithLabelValues(service).Inc()
+}
+
+// CacheHit implements dnssd.Observer.
+func (o *PrometheusObserver) CacheHit(service string) {
+	o.CacheHits.WithLabelValues(service).Inc()
+}
+
+// CacheMiss implements dnssd.Observer.
+func (o *PrometheusObserver) CacheMiss(service string) {
+	o.CacheMisses.WithLabelValues(service).Inc()
+}
+
+// EntryAdded implements dnssd.Observer.
+func (o *PrometheusObserver) EntryAdded(service string) {
+	o.EntriesAdded.WithLabelValues(service).Inc()
+}
+
+// EntryRemoved implements dnssd.Observer.
+func (o *PrometheusObserver) EntryRemoved(service string) {
+	o.EntriesRemoved.WithLabelValues(service).Inc()
+}
+
+// LookupFailed implements dnssd.Observer.
+func (o *PrometheusObserver) LookupFailed(service string, err error) {
+	o.LookupFailures.WithLabelValues(service).Inc()
+}
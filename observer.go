@@ -0,0 +1,74 @@
+package dnssd
+
+import "sync/atomic"
+
+// Observer receives accounting events from lookupType, the browse loop
+// behind LookupType and its variants; MDNSConn, LookupInstance and
+// Resolver do not report to it. It
+// lets operators get visibility into mDNS behavior in production, where
+// today the only signal is log.Debug. The default Observer is a no-op, so
+// installing one is opt-in and existing callers pay nothing for it.
+type Observer interface {
+	// QuerySent is called once per PTR query sent on an interface.
+	QuerySent(service, iface string)
+	// KnownAnswersSuppressed is called with the number of known answers
+	// attached to a query for known-answer suppression.
+	KnownAnswersSuppressed(service, iface string, count int)
+	// MessageReceived is called once per mDNS message received on an
+	// interface.
+	MessageReceived(iface string)
+	// CacheUpdated is called after every received message has been
+	// applied to the cache of a lookup of service, whatever the message
+	// carried.
+	CacheUpdated(service string)
+	// CacheHit is called once per distinct instance name in a received
+	// message that the lookup of service has already seen before.
+	CacheHit(service string)
+	// CacheMiss is called once per distinct instance name in a received
+	// message that the lookup of service sees for the first time.
+	CacheMiss(service string)
+	// EntryAdded is called for every BrowseEntry handed to an AddFunc.
+	EntryAdded(service string)
+	// EntryRemoved is called for every BrowseEntry handed to an RmvFunc.
+	EntryRemoved(service string)
+	// LookupFailed is called when a lookup of service ends in err.
+	LookupFailed(service string, err error)
+}
+
+// observerBox wraps an Observer so every value stored in currentObserver
+// has the same concrete type, which atomic.Value requires.
+type observerBox struct{ Observer }
+
+var currentObserver atomic.Value
+
+func init() {
+	currentObserver.Store(observerBox{nopObserver{}})
+}
+
+// SetObserver installs o as the package-wide Observer. Pass nil to go back
+// to the no-op default. It is safe to call concurrently with lookups in
+// flight.
+func SetObserver(o Observer) {
+	if o == nil {
+		o = nopObserver{}
+	}
+	currentObserver.Store(observerBox{o})
+}
+
+// observer returns the currently installed Observer. It is safe to call
+// concurrently with SetObserver.
+func observer() Observer {
+	return currentObserver.Load().(observerBox).Observer
+}
+
+type nopObserver struct{}
+
+func (nopObserver) QuerySent(service, iface string)                     {}
+func (nopObserver) KnownAnswersSuppressed(service, iface string, n int) {}
+func (nopObserver) MessageReceived(iface string)                        {}
+func (nopObserver) CacheUpdated(service string)                         {}
+func (nopObserver) CacheHit(service string)                             {}
+func (nopObserver) CacheMiss(service string)                            {}
+func (nopObserver) EntryAdded(service string)                           {}
+func (nopObserver) EntryRemoved(service string)                         {}
+func (nopObserver) LookupFailed(service string, err error)              {}
@@ -0,0 +1,314 @@
+package dnssd
+
+import (
+	"context"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/brutella/dnssd/log"
+	"github.com/miekg/dns"
+)
+
+// Resolver sends standard DNS-SD queries to a specific DNS server instead
+// of multicasting them on the local link. Use it to browse DNS-SD data
+// published through a unicast-capable server, e.g. Consul, dnsmasq or a
+// corporate DNS server.
+type Resolver struct {
+	// Addr is the address of the DNS server, e.g. "127.0.0.1:53".
+	Addr string
+
+	// Timeout bounds a single query/response exchange.
+	// Defaults to 2s when zero.
+	Timeout time.Duration
+}
+
+// LookupTypeViaResolver browses for service instances of the given service
+// by sending unicast DNS-SD queries to r, instead of multicasting on the
+// local link like LookupType does. It issues a PTR query to discover
+// instances and follows up with SRV/TXT/A/AAAA queries to resolve them,
+// falling back to TCP whenever a response is truncated. Discovered
+// instances are cached and re-queried as their TTLs run out. It only
+// returns once ctx is done, and then returns ctx.Err().
+func LookupTypeViaResolver(ctx context.Context, service string, addr string, add AddFunc, rmv RmvFunc) (err error) {
+	return NewResolver(addr).LookupType(ctx, service, add, rmv)
+}
+
+// NewResolver returns a Resolver that queries the DNS server at addr.
+func NewResolver(addr string) Resolver {
+	return Resolver{Addr: addr}
+}
+
+// resolverErrorRetryCap bounds how long LookupType waits before retrying
+// after a failed browse. It is independent of minTTL's steady-state cap,
+// so a transient server outage is retried quickly even if a previous
+// successful round had already backed the steady-state interval off
+// towards an hour.
+const resolverErrorRetryCap = 30 * time.Second
+
+// LookupType browses for service instances of the given service by
+// sending unicast DNS-SD queries to r.
+func (r Resolver) LookupType(ctx context.Context, service string, add AddFunc, rmv RmvFunc) (err error) {
+	var cache = NewCache()
+	es := []*BrowseEntry{}
+
+	var steady, retry time.Duration
+	for {
+		found, qerr := r.browse(service, cache)
+
+		var wait time.Duration
+		if qerr != nil {
+			log.Debug.Println("Resolver.browse:", qerr)
+			retry = nextInterval(retry, resolverErrorRetryCap)
+			wait = retry
+		} else {
+			es = r.reconcile(service, es, found, add, rmv)
+			retry = 0
+			steady = nextInterval(steady, r.minTTL(cache, service))
+			wait = steady
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// browse sends a PTR query for service, resolves every answer with a
+// follow-up SRV/TXT/A/AAAA query and updates cache with everything it
+// learns along the way.
+func (r Resolver) browse(service string, cache *Cache) ([]*Query, error) {
+	ptr := new(dns.Msg)
+	ptr.RecursionDesired = true
+	ptr.SetQuestion(service, dns.TypePTR)
+
+	in, err := r.exchange(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	var reqs []*Query
+	reqs = append(reqs, &Query{msg: in})
+	cache.UpdateFrom(&Query{msg: in})
+
+	for _, rr := range in.Answer {
+		p, ok := rr.(*dns.PTR)
+		if !ok {
+			continue
+		}
+
+		m := new(dns.Msg)
+		m.RecursionDesired = true
+		m.Question = []dns.Question{
+			{Name: p.Ptr, Qtype: dns.TypeSRV, Qclass: dns.ClassINET},
+			{Name: p.Ptr, Qtype: dns.TypeTXT, Qclass: dns.ClassINET},
+		}
+
+		resp, err := r.exchange(m)
+		if err != nil {
+			log.Debug.Println("Resolver.exchange (SRV/TXT):", err)
+			continue
+		}
+		cache.UpdateFrom(&Query{msg: resp})
+		reqs = append(reqs, &Query{msg: resp})
+
+		for _, srr := range resp.Answer {
+			srv, ok := srr.(*dns.SRV)
+			if !ok {
+				continue
+			}
+
+			h := new(dns.Msg)
+			h.RecursionDesired = true
+			h.Question = []dns.Question{
+				{Name: srv.Target, Qtype: dns.TypeA, Qclass: dns.ClassINET},
+				{Name: srv.Target, Qtype: dns.TypeAAAA, Qclass: dns.ClassINET},
+			}
+
+			hresp, err := r.exchange(h)
+			if err != nil {
+				log.Debug.Println("Resolver.exchange (A/AAAA):", err)
+				continue
+			}
+			cache.UpdateFrom(&Query{msg: hresp})
+			reqs = append(reqs, &Query{msg: hresp})
+		}
+	}
+
+	return reqs, nil
+}
+
+// exchange sends m to r.Addr over UDP, retrying over TCP when the UDP
+// response comes back truncated.
+func (r Resolver) exchange(m *dns.Msg) (*dns.Msg, error) {
+	in, err := r.client("udp").Exchange(m, r.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Truncated {
+		in, err = r.client("tcp").Exchange(m, r.Addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return in, nil
+}
+
+func (r Resolver) client(net string) interface {
+	Exchange(m *dns.Msg, addr string) (*dns.Msg, error)
+} {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	c := &dns.Client{Net: net, Timeout: timeout}
+	return clientExchanger{c}
+}
+
+// clientExchanger adapts *dns.Client.Exchange to drop the round-trip time
+// return value, which callers here have no use for.
+type clientExchanger struct {
+	c *dns.Client
+}
+
+func (e clientExchanger) Exchange(m *dns.Msg, addr string) (*dns.Msg, error) {
+	in, _, err := e.c.Exchange(m, addr)
+	return in, err
+}
+
+// reconcile diffs found against es and invokes add/rmv so callers only
+// ever see each instance appear or disappear once.
+func (r Resolver) reconcile(service string, es []*BrowseEntry, found []*Query, add AddFunc, rmv RmvFunc) []*BrowseEntry {
+	cache := NewCache()
+	for _, q := range found {
+		cache.UpdateFrom(q)
+	}
+
+	next := []*BrowseEntry{}
+	for _, srv := range cache.Services() {
+		if srv.ServiceName() != service {
+			continue
+		}
+
+		e := BrowseEntry{
+			IPs:    srv.IPs,
+			Host:   srv.Host,
+			Port:   srv.Port,
+			Name:   srv.Name,
+			Type:   srv.Type,
+			Domain: srv.Domain,
+			Text:   srv.Text,
+		}
+		next = append(next, &e)
+
+		switch existing := findEntry(es, e); {
+		case existing == nil:
+			add(e)
+		case !entriesEqual(*existing, e):
+			// Same instance, but its address or TXT data changed since
+			// the last poll: tell the caller it's a different entry
+			// instead of silently keeping the stale one around.
+			rmv(*existing)
+			add(e)
+		}
+	}
+
+	for _, e := range es {
+		if existing := findEntry(next, *e); existing == nil {
+			rmv(*e)
+		}
+	}
+
+	return next
+}
+
+// findEntry returns the entry in es identifying the same instance as e
+// (by Name/Type/Domain), or nil if there isn't one.
+func findEntry(es []*BrowseEntry, e BrowseEntry) *BrowseEntry {
+	for _, existing := range es {
+		if existing.Name == e.Name && existing.Type == e.Type && existing.Domain == e.Domain {
+			return existing
+		}
+	}
+	return nil
+}
+
+// entriesEqual reports whether a and b carry the same resolved data,
+// ignoring ordering of IPs.
+func entriesEqual(a, b BrowseEntry) bool {
+	if a.Host != b.Host || a.Port != b.Port {
+		return false
+	}
+	if !ipsEqual(a.IPs, b.IPs) {
+		return false
+	}
+	if len(a.Text) != len(b.Text) {
+		return false
+	}
+	for k, v := range a.Text {
+		if b.Text[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ipsEqual reports whether a and b contain the same set of IPs,
+// regardless of order.
+func ipsEqual(a, b []net.IP) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	as := make([]string, len(a))
+	bs := make([]string, len(b))
+	for i, ip := range a {
+		as[i] = ip.String()
+	}
+	for i, ip := range b {
+		bs[i] = ip.String()
+	}
+	sort.Strings(as)
+	sort.Strings(bs)
+
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// minTTL returns the smallest TTL among cached services matching service,
+// used to pace re-queries. It falls back to 1h when nothing is cached.
+func (r Resolver) minTTL(cache *Cache, service string) time.Duration {
+	min := time.Hour
+	for _, srv := range cache.Services() {
+		if srv.ServiceName() != service {
+			continue
+		}
+		if srv.TTL > 0 && srv.TTL < min {
+			min = srv.TTL
+		}
+	}
+	return min
+}
+
+// nextInterval starts at one second and doubles on every call, capped at
+// ttl, so re-queries are spaced no further apart than the cached TTL.
+func nextInterval(current, ttl time.Duration) time.Duration {
+	if current == 0 {
+		current = time.Second
+	} else {
+		current *= 2
+	}
+	if current > ttl {
+		current = ttl
+	}
+	return current
+}
@@ -0,0 +1,60 @@
+package dnssd
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNextInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		current time.Duration
+		ttl     time.Duration
+		want    time.Duration
+	}{
+		{"starts at one second", 0, time.Hour, time.Second},
+		{"doubles", 4 * time.Second, time.Hour, 8 * time.Second},
+		{"caps at ttl", 20 * time.Second, 30 * time.Second, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextInterval(tt.current, tt.ttl); got != tt.want {
+				t.Errorf("nextInterval(%v, %v) = %v, want %v", tt.current, tt.ttl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindEntry(t *testing.T) {
+	a := &BrowseEntry{Name: "A", Type: "_http._tcp", Domain: "local"}
+	es := []*BrowseEntry{a}
+
+	if got := findEntry(es, BrowseEntry{Name: "A", Type: "_http._tcp", Domain: "local"}); got != a {
+		t.Errorf("findEntry() = %v, want %v", got, a)
+	}
+	if got := findEntry(es, BrowseEntry{Name: "B", Type: "_http._tcp", Domain: "local"}); got != nil {
+		t.Errorf("findEntry() = %v, want nil", got)
+	}
+}
+
+func TestEntriesEqual(t *testing.T) {
+	ip1 := net.ParseIP("10.0.0.1")
+	ip2 := net.ParseIP("10.0.0.2")
+
+	a := BrowseEntry{Host: "host.local.", Port: 80, IPs: []net.IP{ip1, ip2}, Text: map[string]string{"k": "v"}}
+	sameOrder := BrowseEntry{Host: "host.local.", Port: 80, IPs: []net.IP{ip2, ip1}, Text: map[string]string{"k": "v"}}
+	diffIP := BrowseEntry{Host: "host.local.", Port: 80, IPs: []net.IP{ip1}, Text: map[string]string{"k": "v"}}
+	diffText := BrowseEntry{Host: "host.local.", Port: 80, IPs: []net.IP{ip1, ip2}, Text: map[string]string{"k": "other"}}
+
+	if !entriesEqual(a, sameOrder) {
+		t.Errorf("entriesEqual() = false for entries differing only in IP order, want true")
+	}
+	if entriesEqual(a, diffIP) {
+		t.Errorf("entriesEqual() = true for entries with different IPs, want false")
+	}
+	if entriesEqual(a, diffText) {
+		t.Errorf("entriesEqual() = true for entries with different Text, want false")
+	}
+}
@@ -0,0 +1,43 @@
+package dnssd
+
+import (
+	"github.com/brutella/dnssd/log"
+	"github.com/miekg/dns"
+)
+
+// Responder withdraws a single service instance's PTR, SRV and TXT
+// records via mDNS with an RFC 6762 §10.1 goodbye when the instance is
+// unregistered. It does not announce or answer queries for the records.
+type Responder struct {
+	conn MDNSConn
+	ptr  dns.RR
+	srv  dns.RR
+	txt  dns.RR
+}
+
+// NewResponder creates a Responder that sends goodbyes for ptr, srv and
+// txt of a single service instance over conn.
+func NewResponder(conn MDNSConn, ptr, srv, txt dns.RR) *Responder {
+	return &Responder{conn: conn, ptr: ptr, srv: srv, txt: txt}
+}
+
+// Unregister announces a goodbye for the service instance's PTR, SRV and
+// TXT records, so peers remove it immediately instead of waiting for the
+// TTL to lapse. The shared host A/AAAA records are left untouched, since
+// other services on the same host may still depend on them.
+func (r *Responder) Unregister() error {
+	m := new(dns.Msg)
+	m.Response = true
+	m.Answer = GoodbyeRecords(r.ptr, r.srv, r.txt)
+
+	var err error
+	for _, iface := range MulticastInterfaces() {
+		iface := iface
+		if sendErr := r.conn.SendQuery(&Query{msg: m.Copy(), iface: iface}); sendErr != nil {
+			log.Debug.Println("Responder.Unregister:", sendErr)
+			err = sendErr
+		}
+	}
+
+	return err
+}